@@ -20,8 +20,13 @@ type PVector struct {
 	hash uint32
 }
 
-// vnode is a node in the trie for PVector
+// vnode is a node in the trie for PVector.
+//
+// edit is the transient ownership token for this node, or nil if the
+// node is immutable. A node may be mutated in place only by the
+// TPVector holding the matching token; see ensureEditable.
 type vnode struct {
+	edit  *editToken
 	array []interface{}
 }
 
@@ -45,22 +50,20 @@ var (
 
 // Create a PVector from an ISeq
 func NewPVectorFromISeq(items iseq.Seq) *PVector {
-	// TODO: redo when we have transients
-	var ret iseq.PVector = EmptyPVector
+	ret := EmptyPVector.AsTransient()
 	for ; items != nil; items = items.Next() {
-		ret = ret.ConsV(items.First())
+		ret = ret.Conj(items.First())
 	}
-	return ret.(*PVector)
+	return ret.Persistent()
 }
 
 // Create a PVector from a slice (of interface{})
 func NewPVectorFromSlice(items []interface{}) *PVector {
-	// TODO: redo when we have transients
-	var ret iseq.PVector = EmptyPVector
+	ret := EmptyPVector.AsTransient()
 	for _, item := range items {
-		ret = ret.ConsV(item)
+		ret = ret.Conj(item)
 	}
-	return ret.(*PVector)
+	return ret.Persistent()
 }
 
 // Create a PVector from the given arguments
@@ -184,14 +187,14 @@ func (v *PVector) ConsV(o interface{}) iseq.PVector {
 		return &PVector{AMeta: AMeta{v.meta}, cnt: v.cnt + 1, shift: v.shift, root: v.root, tail: newTail}
 	}
 	// full tail, push into tree
-	tailNode := &vnode{v.tail}
+	tailNode := &vnode{array: v.tail}
 	newShift := v.shift
 
 	var newRoot *vnode
 
 	// overflow root?
 	if (v.cnt >> baseShift) > (1 << v.shift) {
-		newRoot = &vnode{make([]interface{}, branchFactor)}
+		newRoot = &vnode{array: make([]interface{}, branchFactor)}
 		newRoot.array[0] = v.root
 		newRoot.array[1] = newPath(v.shift, tailNode)
 		newShift = newShift + baseShift
@@ -210,7 +213,7 @@ func (v *PVector) pushTail(level uint, parent *vnode, tailNode *vnode) *vnode {
 	subidx := ((v.cnt - 1) >> level) & indexMask
 	newArray := make([]interface{}, len(parent.array))
 	copy(newArray, parent.array)
-	ret := &vnode{newArray}
+	ret := &vnode{array: newArray}
 
 	var nodeToInsert *vnode
 	if level == baseShift {
@@ -322,7 +325,7 @@ func (v *PVector) popTail(level uint, node *vnode) *vnode {
 		}
 		newArray := make([]interface{}, len(node.array))
 		copy(newArray, node.array)
-		return &vnode{newArray}
+		return &vnode{array: newArray}
 	} else if subidx == 0 {
 		return nil
 	}
@@ -330,15 +333,10 @@ func (v *PVector) popTail(level uint, node *vnode) *vnode {
 	newArray := make([]interface{}, len(node.array))
 	copy(newArray, node.array)
 	newArray[subidx] = nil
-	return &vnode{newArray}
+	return &vnode{array: newArray}
 }
 
-// interface Reversible
-
-func (v *PVector) Rseq() iseq.Seq {
-	// TODO: implment Rseq
-	return nil
-}
+// interface Reversible is implemented by Rseq in pvector_functional.go
 
 // utilities
 
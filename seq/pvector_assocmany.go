@@ -0,0 +1,118 @@
+// Copyright 2014 David Miller. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import "errors"
+
+// AssocManyE returns a new PVector with every index in updates set to
+// its corresponding value, or an error if any index is out of range.
+//
+// Rather than calling AssocN once per update - which allocates one
+// new path per update, O(len(updates) * log32(v.Count())) nodes in
+// total - it groups updates by their top-level subindex, recurses
+// into each affected child exactly once with the updates routed to
+// it, and at each level allocates a single new node holding all of
+// that level's updated children. This costs O(len(updates) +
+// len(updates)*log32(v.Count())/branchFactor) allocations rather than
+// O(len(updates)*log32(v.Count())), which matters when applying many
+// scattered updates (e.g. diff application).
+func (v *PVector) AssocManyE(updates map[int]interface{}) (*PVector, error) {
+	for i := range updates {
+		if i < 0 || i >= v.cnt {
+			return nil, errors.New("Index out of bounds in PVector")
+		}
+	}
+	if len(updates) == 0 {
+		return v, nil
+	}
+
+	to := v.tailoff()
+	newTail := v.tail
+	newRoot := v.root
+
+	var tailUpdates map[int]interface{}
+	var treeUpdates map[int]interface{}
+	for i, val := range updates {
+		if i >= to {
+			if tailUpdates == nil {
+				tailUpdates = make(map[int]interface{})
+			}
+			tailUpdates[i] = val
+		} else {
+			if treeUpdates == nil {
+				treeUpdates = make(map[int]interface{})
+			}
+			treeUpdates[i] = val
+		}
+	}
+
+	if tailUpdates != nil {
+		newTail = make([]interface{}, len(v.tail))
+		copy(newTail, v.tail)
+		for i, val := range tailUpdates {
+			newTail[i&indexMask] = val
+		}
+	}
+
+	if treeUpdates != nil {
+		newRoot = assocMany(v.shift, v.root, treeUpdates)
+	}
+
+	return &PVector{AMeta: AMeta{v.meta}, cnt: v.cnt, shift: v.shift, root: newRoot, tail: newTail}, nil
+}
+
+// AssocMany is AssocManyE, but panics instead of returning an error.
+func (v *PVector) AssocMany(updates map[int]interface{}) *PVector {
+	result, err := v.AssocManyE(updates)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// AssocManyPairs is AssocMany taking its updates as alternating
+// (index, value) arguments rather than a map.
+func (v *PVector) AssocManyPairs(pairs ...interface{}) *PVector {
+	if len(pairs)%2 != 0 {
+		panic("AssocManyPairs requires an even number of arguments")
+	}
+	updates := make(map[int]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		updates[pairs[i].(int)] = pairs[i+1]
+	}
+	return v.AssocMany(updates)
+}
+
+// assocMany applies updates (keyed by absolute vector index) to node,
+// which is at the given trie level, grouping by child subindex so
+// each affected child is recursed into exactly once.
+func assocMany(level uint, node *vnode, updates map[int]interface{}) *vnode {
+	newArray := make([]interface{}, len(node.array))
+	copy(newArray, node.array)
+
+	if level == 0 {
+		for i, val := range updates {
+			newArray[i&indexMask] = val
+		}
+		return &vnode{array: newArray}
+	}
+
+	grouped := make(map[int]map[int]interface{})
+	for i, val := range updates {
+		subidx := (i >> level) & indexMask
+		group := grouped[subidx]
+		if group == nil {
+			group = make(map[int]interface{})
+			grouped[subidx] = group
+		}
+		group[i] = val
+	}
+
+	for subidx, group := range grouped {
+		newArray[subidx] = assocMany(level-baseShift, newArray[subidx].(*vnode), group)
+	}
+
+	return &vnode{array: newArray}
+}
@@ -0,0 +1,214 @@
+// Copyright 2014 David Miller. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import "github.com/dmiller/go-seq/iseq"
+
+// rseq is a lazy, chunked sequence over a PVector produced in reverse
+// order. It mirrors chunkedSeq, walking the same 32-element chunks
+// via arrayFor, but with offset and i decrementing from the end of
+// the vector back to the start.
+type rseq struct {
+	node   []interface{} // the current chunk
+	i      int           // index within node of the current element
+	vec    *PVector
+	offset int // vec-index of node[0]
+	AMeta
+}
+
+// Rseq returns v's elements in reverse order as a lazy, chunked Seq.
+func (v *PVector) Rseq() iseq.Seq {
+	if v.cnt == 0 {
+		return nil
+	}
+	return &rseq{vec: v, node: v.tail, i: len(v.tail) - 1, offset: v.tailoff()}
+}
+
+func (r *rseq) WithMeta(meta iseq.PMap) iseq.MetaW {
+	return &rseq{AMeta: AMeta{meta}, vec: r.vec, node: r.node, i: r.i, offset: r.offset}
+}
+
+func (r *rseq) Seq() iseq.Seq {
+	return r
+}
+
+func (r *rseq) First() interface{} {
+	return r.node[r.i]
+}
+
+func (r *rseq) Next() iseq.Seq {
+	if r.i > 0 {
+		return &rseq{AMeta: r.AMeta, vec: r.vec, node: r.node, i: r.i - 1, offset: r.offset}
+	}
+	if r.offset == 0 {
+		return nil
+	}
+	prevOffset := r.offset - branchFactor
+	return &rseq{AMeta: r.AMeta, vec: r.vec, node: r.vec.arrayFor(prevOffset), i: branchFactor - 1, offset: prevOffset}
+}
+
+// More is Next, except that it returns the canonical empty seq rather
+// than nil once exhausted.
+func (r *rseq) More() iseq.Seq {
+	if n := r.Next(); n != nil {
+		return n
+	}
+	return CachedEmptyList
+}
+
+func (r *rseq) Cons(o interface{}) iseq.PCollection {
+	return r.ConsS(o)
+}
+
+// ConsS returns a new seq with o prepended to r.
+func (r *rseq) ConsS(o interface{}) iseq.Seq {
+	return &consSeq{first: o, rest: r}
+}
+
+func (r *rseq) Count() int {
+	n := 0
+	for s := iseq.Seq(r); s != nil; s = s.Next() {
+		n++
+	}
+	return n
+}
+
+// consSeq is a minimal cons cell used to implement ConsS for the seq
+// types in this file: first, followed by the elements of rest.
+type consSeq struct {
+	first interface{}
+	rest  iseq.Seq
+	AMeta
+}
+
+func (c *consSeq) WithMeta(meta iseq.PMap) iseq.MetaW {
+	return &consSeq{AMeta: AMeta{meta}, first: c.first, rest: c.rest}
+}
+
+func (c *consSeq) Seq() iseq.Seq { return c }
+
+func (c *consSeq) First() interface{} { return c.first }
+
+func (c *consSeq) Next() iseq.Seq { return c.rest }
+
+func (c *consSeq) More() iseq.Seq {
+	if c.rest != nil {
+		return c.rest
+	}
+	return CachedEmptyList
+}
+
+func (c *consSeq) Cons(o interface{}) iseq.PCollection {
+	return c.ConsS(o)
+}
+
+func (c *consSeq) ConsS(o interface{}) iseq.Seq {
+	return &consSeq{first: o, rest: c}
+}
+
+func (c *consSeq) Count() int {
+	n := 1
+	for s := c.rest; s != nil; s = s.Next() {
+		n++
+	}
+	return n
+}
+
+// eachChunk invokes fn once per 32-element chunk of v's trie, in
+// order, followed by v's (possibly shorter) tail, passing the backing
+// array and the number of valid leading entries in it.
+func (v *PVector) eachChunk(fn func(chunk []interface{}, n int)) {
+	to := v.tailoff()
+	for start := 0; start < to; start += branchFactor {
+		fn(v.arrayFor(start), branchFactor)
+	}
+	if len(v.tail) > 0 {
+		fn(v.tail, len(v.tail))
+	}
+}
+
+// eachChunkReverse is eachChunk in reverse chunk order: the tail
+// first, then trie chunks from the last back to the first.
+func (v *PVector) eachChunkReverse(fn func(chunk []interface{}, n int)) {
+	if len(v.tail) > 0 {
+		fn(v.tail, len(v.tail))
+	}
+	to := v.tailoff()
+	for start := to - branchFactor; start >= 0; start -= branchFactor {
+		fn(v.arrayFor(start), branchFactor)
+	}
+}
+
+// Map returns a new PVector with f applied to every element. It walks
+// the trie node by node, allocating new vnodes holding the
+// transformed leaves, so it is O(n) work with no rebalancing - unlike
+// building the result with repeated ConsV calls.
+func (v *PVector) Map(f func(interface{}) interface{}) *PVector {
+	if v.cnt == 0 {
+		return v
+	}
+
+	newTail := make([]interface{}, len(v.tail))
+	for i, x := range v.tail {
+		newTail[i] = f(x)
+	}
+
+	return &PVector{AMeta: AMeta{v.meta}, cnt: v.cnt, shift: v.shift, root: mapNode(v.root, v.shift, f), tail: newTail}
+}
+
+func mapNode(node *vnode, level uint, f func(interface{}) interface{}) *vnode {
+	newArray := make([]interface{}, len(node.array))
+	if level == 0 {
+		for i, x := range node.array {
+			newArray[i] = f(x)
+		}
+	} else {
+		for i, c := range node.array {
+			if c != nil {
+				newArray[i] = mapNode(c.(*vnode), level-baseShift, f)
+			}
+		}
+	}
+	return &vnode{array: newArray}
+}
+
+// Filter returns a new PVector holding only the elements for which
+// pred returns true, built via the transient path chunk by chunk.
+func (v *PVector) Filter(pred func(interface{}) bool) *PVector {
+	t := EmptyPVector.AsTransient()
+	v.eachChunk(func(chunk []interface{}, n int) {
+		for i := 0; i < n; i++ {
+			if pred(chunk[i]) {
+				t.Conj(chunk[i])
+			}
+		}
+	})
+	return t.Persistent()
+}
+
+// Foldl reduces v from the left: f(...f(f(seed, v[0]), v[1])..., v[n-1]).
+// It walks v chunk by chunk via arrayFor rather than calling Nth per
+// element, to avoid a trie descent for every index.
+func (v *PVector) Foldl(f func(acc, x interface{}) interface{}, seed interface{}) interface{} {
+	acc := seed
+	v.eachChunk(func(chunk []interface{}, n int) {
+		for i := 0; i < n; i++ {
+			acc = f(acc, chunk[i])
+		}
+	})
+	return acc
+}
+
+// Foldr reduces v from the right: f(v[0], f(v[1], ...f(v[n-1], seed))).
+// Like Foldl, it walks v chunk by chunk rather than by repeated Nth.
+func (v *PVector) Foldr(f func(x, acc interface{}) interface{}, seed interface{}) interface{} {
+	acc := seed
+	v.eachChunkReverse(func(chunk []interface{}, n int) {
+		for i := n - 1; i >= 0; i-- {
+			acc = f(chunk[i], acc)
+		}
+	})
+	return acc
+}
@@ -0,0 +1,93 @@
+// Copyright 2014 David Miller. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import "iter"
+
+// All returns a Go 1.23 range-over-func iterator over v's (index,
+// value) pairs, in order. Like chunkedSeq and rseq, it walks the trie
+// via arrayFor a 32-element chunk at a time rather than calling Nth
+// per index, so iteration is O(n) total.
+func (v *PVector) All() iter.Seq2[int, interface{}] {
+	return v.Range(0, v.cnt)
+}
+
+// Values returns a Go 1.23 range-over-func iterator over v's values,
+// in order.
+func (v *PVector) Values() iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		for _, x := range v.All() {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns a Go 1.23 range-over-func iterator over v's
+// (index, value) pairs in reverse order, walking the same chunks as
+// Rseq but as an iterator rather than a lazy Seq.
+func (v *PVector) Backward() iter.Seq2[int, interface{}] {
+	return func(yield func(int, interface{}) bool) {
+		idx := v.cnt - 1
+		for j := len(v.tail) - 1; j >= 0; j-- {
+			if !yield(idx, v.tail[j]) {
+				return
+			}
+			idx--
+		}
+
+		to := v.tailoff()
+		for start := to - branchFactor; start >= 0; start -= branchFactor {
+			chunk := v.arrayFor(start)
+			for j := branchFactor - 1; j >= 0; j-- {
+				if !yield(idx, chunk[j]) {
+					return
+				}
+				idx--
+			}
+		}
+	}
+}
+
+// Range returns a Go 1.23 range-over-func iterator over v's (index,
+// value) pairs for index in [start, end). It walks the same 32-wide
+// chunks as All, entering and leaving mid-chunk as needed rather than
+// materializing a subvector first.
+func (v *PVector) Range(start, end int) iter.Seq2[int, interface{}] {
+	if start < 0 || end > v.cnt || start > end {
+		panic("Range index out of bounds")
+	}
+
+	return func(yield func(int, interface{}) bool) {
+		to := v.tailoff()
+		idx := start
+		for chunkStart := (start / branchFactor) * branchFactor; chunkStart < end; chunkStart += branchFactor {
+			var chunk []interface{}
+			var chunkLen int
+			if chunkStart >= to {
+				chunk, chunkLen = v.tail, len(v.tail)
+			} else {
+				chunk, chunkLen = v.arrayFor(chunkStart), branchFactor
+			}
+
+			lo := 0
+			if chunkStart < start {
+				lo = start - chunkStart
+			}
+			hi := chunkLen
+			if chunkStart+chunkLen > end {
+				hi = end - chunkStart
+			}
+
+			for j := lo; j < hi; j++ {
+				if !yield(idx, chunk[j]) {
+					return
+				}
+				idx++
+			}
+		}
+	}
+}
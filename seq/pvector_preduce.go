@@ -0,0 +1,125 @@
+// Copyright 2014 David Miller. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"runtime"
+	"sync"
+)
+
+// preduceSerialThreshold is the element count below which PReduce
+// falls back to a plain serial Foldl rather than paying for goroutine
+// fan-out.
+const preduceSerialThreshold = 8 * branchFactor * branchFactor
+
+// PReduce reduces v in parallel, exploiting the structural
+// parallelism of the 32-way trie: every leaf chunk but possibly the
+// last has exactly branchFactor entries, so subtrees can be reduced
+// independently and merged back together.
+//
+// f reduces within a single leaf chunk, seeded by identity. combine
+// merges the results of two subtrees; if combine is nil, f is used
+// for both roles, as is appropriate when f is associative and
+// identity is its identity element. PReduce first walks down to a
+// computed split level so that the number of subtrees roughly matches
+// runtime.GOMAXPROCS, then forks exactly one goroutine per subtree,
+// bounded by a worker pool of that size; each goroutine reduces its
+// whole subtree serially, so no goroutine ever blocks waiting for a
+// pool slot held by another goroutine it is waiting on. Results are
+// combined back up in array order, so combination order is
+// deterministic for a given v regardless of scheduling. v's tail is
+// always reduced on the calling goroutine.
+func (v *PVector) PReduce(f func(a, b interface{}) interface{}, identity interface{}, combine func(a, b interface{}) interface{}) interface{} {
+	if combine == nil {
+		combine = f
+	}
+	if v.cnt == 0 {
+		return identity
+	}
+	if v.cnt < preduceSerialThreshold {
+		return v.Foldl(f, identity)
+	}
+
+	splitShift := v.shift
+	maxProcs := uint(runtime.GOMAXPROCS(0))
+	for splitShift > baseShift && (uint(1)<<(v.shift-splitShift)) < maxProcs {
+		splitShift -= baseShift
+	}
+
+	subtrees := collectSubtrees(v.root, v.shift, splitShift)
+	results := make([]interface{}, len(subtrees))
+
+	sem := make(chan struct{}, maxProcs)
+	var wg sync.WaitGroup
+	for i, st := range subtrees {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, st subtreeNode) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = reduceNode(st.node, st.level, f, identity, combine)
+		}(i, st)
+	}
+	wg.Wait()
+
+	acc := identity
+	for _, r := range results {
+		acc = combine(acc, r)
+	}
+	tailResult := reduceChunk(v.tail, len(v.tail), f, identity)
+	return combine(acc, tailResult)
+}
+
+// subtreeNode names a node together with its level, so it can be
+// reduced on its own once handed to a worker.
+type subtreeNode struct {
+	node  *vnode
+	level uint
+}
+
+// collectSubtrees walks node down from level, stopping and recording
+// each subtree it reaches at or below splitShift (or at a leaf,
+// whichever comes first). It does no reducing itself, so it is cheap
+// to run before any goroutines are started.
+func collectSubtrees(node *vnode, level uint, splitShift uint) []subtreeNode {
+	if level == 0 || level <= splitShift {
+		return []subtreeNode{{node: node, level: level}}
+	}
+
+	var subtrees []subtreeNode
+	for _, c := range node.array {
+		if c != nil {
+			subtrees = append(subtrees, collectSubtrees(c.(*vnode), level-baseShift, splitShift)...)
+		}
+	}
+	return subtrees
+}
+
+// reduceChunk reduces the first n entries of chunk with f, seeded by
+// identity.
+func reduceChunk(chunk []interface{}, n int, f func(a, b interface{}) interface{}, identity interface{}) interface{} {
+	acc := identity
+	for i := 0; i < n; i++ {
+		acc = f(acc, chunk[i])
+	}
+	return acc
+}
+
+// reduceNode reduces node's whole subtree serially, on the calling
+// goroutine - it never forks, so it is safe to run inside a worker
+// that already holds a pool slot.
+func reduceNode(node *vnode, level uint, f func(a, b interface{}) interface{}, identity interface{}, combine func(a, b interface{}) interface{}) interface{} {
+	if level == 0 {
+		return reduceChunk(node.array, len(node.array), f, identity)
+	}
+
+	acc := identity
+	for _, c := range node.array {
+		if c != nil {
+			acc = combine(acc, reduceNode(c.(*vnode), level-baseShift, f, identity, combine))
+		}
+	}
+	return acc
+}
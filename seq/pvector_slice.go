@@ -0,0 +1,298 @@
+// Copyright 2014 David Miller. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"errors"
+
+	"github.com/dmiller/go-seq/iseq"
+	"github.com/dmiller/go-seq/sequtil"
+)
+
+// Take returns a PVector containing the first n elements of v. If n
+// is at least v.Count(), v itself is returned. The result shares trie
+// structure with v: if n falls within the current tail, only the tail
+// is truncated; otherwise the new tail is peeled off the trie and the
+// root is rebuilt in a single popTail-style pass that drops the
+// subtrees past index n-1, rather than popping one element at a time.
+func (v *PVector) Take(n int) *PVector {
+	if n >= v.cnt {
+		return v
+	}
+	if n <= 0 {
+		return EmptyPVector.WithMeta(v.meta).(*PVector)
+	}
+
+	to := v.tailoff()
+	if n > to {
+		newTail := make([]interface{}, n-to)
+		copy(newTail, v.tail)
+		return &PVector{AMeta: AMeta{v.meta}, cnt: n, shift: v.shift, root: v.root, tail: newTail}
+	}
+
+	idx := n - 1
+	leaf := v.arrayFor(idx)
+	tailLen := (idx & indexMask) + 1
+	newTail := make([]interface{}, tailLen)
+	copy(newTail, leaf[:tailLen])
+
+	newRoot := truncateNode(v.shift, v.root, idx)
+	newShift := v.shift
+	if newRoot == nil {
+		newRoot = emptyVnode
+		newShift = baseShift
+	} else {
+		for newShift > baseShift && newRoot.array[1] == nil {
+			child, ok := newRoot.array[0].(*vnode)
+			if !ok {
+				break
+			}
+			newRoot = child
+			newShift -= baseShift
+		}
+	}
+
+	return &PVector{AMeta: AMeta{v.meta}, cnt: n, shift: newShift, root: newRoot, tail: newTail}
+}
+
+// truncateNode returns node, rooted at level, with every subtree past
+// idx dropped - the leaf holding idx itself is dropped too, since it
+// becomes the new vector's tail rather than staying in the trie. It
+// mirrors popTail, but against an arbitrary cutoff index instead of
+// always cnt-2.
+func truncateNode(level uint, node *vnode, idx int) *vnode {
+	subidx := (idx >> level) & indexMask
+	if level > baseShift {
+		newChild := truncateNode(level-baseShift, node.array[subidx].(*vnode), idx)
+		if newChild == nil && subidx == 0 {
+			return nil
+		}
+		newArray := make([]interface{}, branchFactor)
+		copy(newArray[:subidx], node.array[:subidx])
+		newArray[subidx] = newChild
+		return &vnode{array: newArray}
+	} else if subidx == 0 {
+		return nil
+	}
+
+	newArray := make([]interface{}, branchFactor)
+	copy(newArray[:subidx], node.array[:subidx])
+	return &vnode{array: newArray}
+}
+
+// Drop returns a PVector-like view of v with the first n elements
+// removed. The view is an O(1)-space offset wrapper around v (a
+// subPVector): it retains a reference to v in full, so no storage is
+// reclaimed until either v itself is collected or Shrink is called on
+// the result.
+func (v *PVector) Drop(n int) iseq.PVector {
+	if n <= 0 {
+		return v
+	}
+	if n >= v.cnt {
+		return EmptyPVector.WithMeta(v.meta).(iseq.PVector)
+	}
+	return &subPVector{v: v, start: n, end: v.cnt}
+}
+
+// SplitAt is equivalent to the pair (v.Take(n), v.Drop(n)).
+func (v *PVector) SplitAt(n int) (*PVector, iseq.PVector) {
+	return v.Take(n), v.Drop(n)
+}
+
+// Slice returns a new, compacted PVector holding v[start:end]. Unlike
+// Drop, the result does not retain v.
+func (v *PVector) Slice(start, end int) *PVector {
+	if start < 0 || end > v.cnt || start > end {
+		panic("Slice index out of range")
+	}
+	if start == end {
+		return EmptyPVector.WithMeta(v.meta).(*PVector)
+	}
+
+	t := EmptyPVector.AsTransient()
+	for i := start; i < end; i++ {
+		t.Conj(v.Nth(i))
+	}
+	return t.Persistent()
+}
+
+// subPVector is an offset view onto [start, end) of an underlying
+// PVector. It trades O(1) construction for retaining the whole of the
+// underlying vector; callers who want to free that memory can call
+// Shrink to materialize an independent, compacted PVector.
+type subPVector struct {
+	v     *PVector
+	start int
+	end   int
+	AMeta
+}
+
+// Shrink materializes an independent, compacted PVector holding just
+// this view's elements, via the transient path, so the underlying
+// vector can be released.
+func (s *subPVector) Shrink() *PVector {
+	t := EmptyPVector.AsTransient()
+	for i := s.start; i < s.end; i++ {
+		t.Conj(s.v.Nth(i))
+	}
+	return t.Persistent()
+}
+
+// interface MetaW
+
+func (s *subPVector) WithMeta(meta iseq.PMap) iseq.MetaW {
+	return &subPVector{AMeta: AMeta{meta}, v: s.v, start: s.start, end: s.end}
+}
+
+// interface Seqable
+
+func (s *subPVector) Seq() iseq.Seq {
+	if s.start == s.end {
+		return nil
+	}
+	return s.Shrink().Seq()
+}
+
+// interface PCollection
+
+func (s *subPVector) Count() int {
+	return s.end - s.start
+}
+
+func (s *subPVector) Cons(o interface{}) iseq.PCollection {
+	return s.ConsV(o)
+}
+
+func (s *subPVector) Empty() iseq.PCollection {
+	return CachedEmptyList.WithMeta(s.Meta()).(iseq.PCollection)
+}
+
+// interface Counted
+
+func (s *subPVector) Count1() int {
+	return s.end - s.start
+}
+
+// interface Indexed
+
+func (s *subPVector) Nth(i int) interface{} {
+	if i < 0 || i >= s.Count() {
+		panic("Array index out of bounds")
+	}
+	return s.v.Nth(s.start + i)
+}
+
+func (s *subPVector) NthD(i int, notFound interface{}) interface{} {
+	if i >= 0 && i < s.Count() {
+		return s.Nth(i)
+	}
+	return notFound
+}
+
+func (s *subPVector) NthE(i int) (interface{}, error) {
+	if i >= 0 && i < s.Count() {
+		return s.Nth(i), nil
+	}
+	return nil, errors.New("Index out of bounds in subPVector")
+}
+
+// interface Lookup
+
+func (s *subPVector) ValAt(key interface{}) interface{} {
+	return s.ValAtD(key, nil)
+}
+
+func (s *subPVector) ValAtD(key interface{}, notFound interface{}) interface{} {
+	if i, ok := key.(int); ok && i >= 0 && i < s.Count() {
+		return s.Nth(i)
+	}
+	return notFound
+}
+
+// interface Associative
+
+func (s *subPVector) ContainsKey(key interface{}) bool {
+	i, ok := key.(int)
+	return ok && i >= 0 && i < s.Count()
+}
+
+func (s *subPVector) EntryAt(key interface{}) iseq.MapEntry {
+	if i, ok := key.(int); ok && i >= 0 && i < s.Count() {
+		return MapEntry{key, s.Nth(i)}
+	}
+	return nil
+}
+
+func (s *subPVector) Assoc(key interface{}, val interface{}) iseq.Associative {
+	if i, ok := key.(int); ok {
+		return s.AssocN(i, val)
+	}
+	panic("Index must be an integer")
+}
+
+// interface PVector
+
+func (s *subPVector) ConsV(o interface{}) iseq.PVector {
+	if s.end == s.v.cnt {
+		return &subPVector{AMeta: s.AMeta, v: s.v.ConsV(o).(*PVector), start: s.start, end: s.end + 1}
+	}
+	return s.Shrink().ConsV(o)
+}
+
+func (s *subPVector) AssocN(i int, val interface{}) iseq.PVector {
+	if i >= 0 && i < s.Count() {
+		return &subPVector{AMeta: s.AMeta, v: s.v.AssocN(s.start+i, val).(*PVector), start: s.start, end: s.end}
+	} else if i == s.Count() {
+		return s.ConsV(val)
+	}
+	panic("Argument out of range")
+}
+
+// interface PStack
+
+func (s *subPVector) Peek() interface{} {
+	if s.Count() > 0 {
+		return s.Nth(s.Count() - 1)
+	}
+	return nil
+}
+
+func (s *subPVector) Pop() iseq.PStack {
+	if s.Count() == 0 {
+		panic("Can't pop empty vector")
+	}
+	if s.Count() == 1 {
+		return EmptyPVector.WithMeta(s.Meta()).(iseq.PStack)
+	}
+	return &subPVector{AMeta: s.AMeta, v: s.v, start: s.start, end: s.end - 1}
+}
+
+// interface Reversible
+
+func (s *subPVector) Rseq() iseq.Seq {
+	return s.Shrink().Rseq()
+}
+
+// interfaces Equivable, Hashable
+
+func (s *subPVector) Equiv(o interface{}) bool {
+	if ov, ok := o.(iseq.PVector); ok {
+		if s.Count1() != ov.Count1() {
+			return false
+		}
+		for i := 0; i < s.Count1(); i++ {
+			if !sequtil.Equiv(s.Nth(i), ov.Nth(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (s *subPVector) Hash() uint32 {
+	return sequtil.HashSeq(s.Seq())
+}
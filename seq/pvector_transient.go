@@ -0,0 +1,256 @@
+// Copyright 2014 David Miller. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import "errors"
+
+// editToken is a unique ownership marker stamped on vnodes created or
+// claimed by a given TPVector. A node may be mutated in place iff its
+// edit field points at the same token as the transient doing the
+// mutating; see ensureEditable. Only its identity matters; the unused
+// byte gives it a non-zero size, so that every new(editToken) is a
+// distinct pointer (pointers to zero-size values may compare equal).
+type editToken struct{ _ byte }
+
+// TPVector is a transient, mutable-in-place counterpart to PVector,
+// analogous to Clojure's TransientVector. It shares trie structure
+// with the PVector it was created from; nodes are copied on first
+// write and stamped with the transient's edit token so that further
+// writes through the same TPVector mutate them in place.
+//
+// A TPVector is single-owner: it must not be used from more than one
+// goroutine at a time, and it becomes unusable once Persistent is
+// called.
+type TPVector struct {
+	cnt   int
+	shift uint
+	root  *vnode
+	tail  []interface{}
+	edit  *editToken
+}
+
+// AsTransient returns a TPVector sharing v's root. v's tail is copied
+// into a new slice with room for branchFactor entries; trie nodes are
+// not copied up front but cloned lazily, the first time a write
+// reaches them, via ensureEditable.
+func (v *PVector) AsTransient() *TPVector {
+	tail := make([]interface{}, len(v.tail), branchFactor)
+	copy(tail, v.tail)
+	return &TPVector{cnt: v.cnt, shift: v.shift, root: v.root, tail: tail, edit: new(editToken)}
+}
+
+// ensureEditable returns a vnode that the given transient may mutate
+// in place: node itself if it already carries a matching edit token,
+// or a freshly stamped copy otherwise. A nil node is treated as an
+// empty, fully-owned node.
+func ensureEditable(node *vnode, edit *editToken) *vnode {
+	if node != nil && node.edit == edit {
+		return node
+	}
+	newArray := make([]interface{}, branchFactor)
+	if node != nil {
+		copy(newArray, node.array)
+	}
+	return &vnode{edit: edit, array: newArray}
+}
+
+// ensureEditable panics if t has already been handed to Persistent.
+func (t *TPVector) ensureEditable() {
+	if t.edit == nil {
+		panic("TPVector used after Persistent() call")
+	}
+}
+
+func (t *TPVector) tailoff() int {
+	if t.cnt < branchFactor {
+		return 0
+	}
+	return ((t.cnt - 1) >> baseShift) << baseShift
+}
+
+// Conj appends o to the end of t, mutating and returning t.
+func (t *TPVector) Conj(o interface{}) *TPVector {
+	t.ensureEditable()
+
+	if t.cnt-t.tailoff() < branchFactor {
+		t.tail = append(t.tail, o)
+		t.cnt++
+		return t
+	}
+
+	// full tail, push into tree
+	tailNode := &vnode{edit: t.edit, array: t.tail}
+	newShift := t.shift
+
+	var newRoot *vnode
+	if (t.cnt >> baseShift) > (1 << t.shift) {
+		newRoot = &vnode{edit: t.edit, array: make([]interface{}, branchFactor)}
+		newRoot.array[0] = t.root
+		newRoot.array[1] = t.newPath(t.shift, tailNode)
+		newShift += baseShift
+	} else {
+		newRoot = t.pushTail(t.shift, t.root, tailNode)
+	}
+
+	t.root = newRoot
+	t.shift = newShift
+	t.tail = make([]interface{}, 1, branchFactor)
+	t.tail[0] = o
+	t.cnt++
+	return t
+}
+
+func (t *TPVector) pushTail(level uint, parent *vnode, tailNode *vnode) *vnode {
+	parent = ensureEditable(parent, t.edit)
+	subidx := ((t.cnt - 1) >> level) & indexMask
+
+	var nodeToInsert *vnode
+	if level == baseShift {
+		nodeToInsert = tailNode
+	} else if child, ok := parent.array[subidx].(*vnode); ok {
+		nodeToInsert = t.pushTail(level-baseShift, child, tailNode)
+	} else {
+		nodeToInsert = t.newPath(level-baseShift, tailNode)
+	}
+	parent.array[subidx] = nodeToInsert
+	return parent
+}
+
+// newPath mirrors the package-level newPath, but stamps every node it
+// allocates with t's edit token so the path stays owned by t.
+func (t *TPVector) newPath(level uint, node *vnode) *vnode {
+	if level == 0 {
+		return node
+	}
+	ret := &vnode{edit: t.edit, array: make([]interface{}, branchFactor)}
+	ret.array[0] = t.newPath(level-baseShift, node)
+	return ret
+}
+
+// AssocN sets the i-th value of t, mutating and returning t. As with
+// PVector.AssocN, i == t.cnt is treated as an append.
+func (t *TPVector) AssocN(i int, val interface{}) *TPVector {
+	t.ensureEditable()
+
+	if i >= 0 && i < t.cnt {
+		if i >= t.tailoff() {
+			t.tail[i&indexMask] = val
+			return t
+		}
+		t.root = t.doAssoc(t.shift, t.root, i, val)
+		return t
+	} else if i == t.cnt {
+		return t.Conj(val)
+	}
+
+	panic("Argument out of range")
+}
+
+func (t *TPVector) doAssoc(level uint, node *vnode, i int, val interface{}) *vnode {
+	node = ensureEditable(node, t.edit)
+	if level == 0 {
+		node.array[i&indexMask] = val
+	} else {
+		subidx := (i >> level) & indexMask
+		node.array[subidx] = t.doAssoc(level-baseShift, node.array[subidx].(*vnode), i, val)
+	}
+	return node
+}
+
+func (t *TPVector) arrayFor(i int) []interface{} {
+	if i < 0 || i >= t.cnt {
+		panic("Array index out of bounds")
+	}
+	if i >= t.tailoff() {
+		return t.tail
+	}
+	node := t.root
+	for level := t.shift; level > 0; level -= baseShift {
+		node = node.array[(i>>level)&indexMask].(*vnode)
+	}
+	return node.array
+}
+
+// Pop removes the last element of t, mutating and returning t.
+func (t *TPVector) Pop() *TPVector {
+	t.ensureEditable()
+
+	if t.cnt == 0 {
+		panic("Can't pop empty TPVector")
+	}
+	if t.cnt == 1 {
+		t.tail = t.tail[:0]
+		t.cnt = 0
+		return t
+	}
+	if t.cnt-t.tailoff() > 1 {
+		t.tail = t.tail[:len(t.tail)-1]
+		t.cnt--
+		return t
+	}
+
+	oldTail := t.arrayFor(t.cnt - 2)
+	newTail := make([]interface{}, branchFactor)
+	copy(newTail, oldTail)
+
+	newRoot := t.popTail(t.shift, t.root)
+	newShift := t.shift
+	if newRoot == nil {
+		newRoot = ensureEditable(emptyVnode, t.edit)
+	}
+	if t.shift > baseShift && newRoot.array[1] == nil {
+		newRoot, _ = newRoot.array[0].(*vnode)
+		newShift -= baseShift
+	}
+
+	t.root = newRoot
+	t.shift = newShift
+	t.tail = newTail
+	t.cnt--
+	return t
+}
+
+func (t *TPVector) popTail(level uint, node *vnode) *vnode {
+	node = ensureEditable(node, t.edit)
+	subidx := ((t.cnt - 2) >> level) & indexMask
+	if level > baseShift {
+		newChild := t.popTail(level-baseShift, node.array[subidx].(*vnode))
+		if newChild == nil && subidx == 0 {
+			return nil
+		}
+		node.array[subidx] = newChild
+		return node
+	} else if subidx == 0 {
+		return nil
+	}
+	node.array[subidx] = nil
+	return node
+}
+
+// Persistent finalizes t into an immutable PVector and clears t's edit
+// token, so t (and any vnode it still owns) can no longer be mutated.
+// Calling Persistent, Conj, AssocN, or Pop on t again panics.
+func (t *TPVector) Persistent() *PVector {
+	t.ensureEditable()
+	t.edit = nil
+
+	trimmedTail := make([]interface{}, t.cnt-t.tailoff())
+	copy(trimmedTail, t.tail)
+	return &PVector{cnt: t.cnt, shift: t.shift, root: t.root, tail: trimmedTail}
+}
+
+// Count1 reports the current number of elements in t.
+func (t *TPVector) Count1() int {
+	return t.cnt
+}
+
+// NthE returns the i-th value of t, or an error if i is out of range.
+func (t *TPVector) NthE(i int) (interface{}, error) {
+	if i < 0 || i >= t.cnt {
+		return nil, errors.New("Index out of bounds in TPVector")
+	}
+	node := t.arrayFor(i)
+	return node[i&indexMask], nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2014 David Miller. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import "testing"
+
+const benchSize = 100000
+
+func BenchmarkNewPVectorFromSliceTransient(b *testing.B) {
+	items := make([]interface{}, benchSize)
+	for i := range items {
+		items[i] = i
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		NewPVectorFromSlice(items)
+	}
+}
+
+func BenchmarkNewPVectorFromSliceConsV(b *testing.B) {
+	items := make([]interface{}, benchSize)
+	for i := range items {
+		items[i] = i
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var ret = EmptyPVector
+		for _, item := range items {
+			ret = ret.ConsV(item).(*PVector)
+		}
+	}
+}